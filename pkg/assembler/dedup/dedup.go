@@ -0,0 +1,196 @@
+//
+// Copyright 2024 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dedup collapses duplicate CertifyLegal and HasSourceAt predicates
+// before they're flushed to the assembler, keeping the most recently
+// scanned entry for each subject and license fact.
+package dedup
+
+import (
+	"strings"
+
+	"github.com/guacsec/guac/pkg/assembler"
+	"github.com/guacsec/guac/pkg/assembler/clients/generated"
+	"github.com/guacsec/guac/pkg/assembler/helpers"
+)
+
+// Metrics reports how effective deduplication has been for an Accumulator
+// so far.
+type Metrics struct {
+	// Seen is the number of CertifyLegal and HasSourceAt predicates added,
+	// before deduplication.
+	Seen int
+	// Deduped is how many of those were collapsed into an existing entry
+	// rather than becoming a new one.
+	Deduped int
+}
+
+// Ratio returns Deduped/Seen, or 0 if nothing has been added yet.
+func (m Metrics) Ratio() float64 {
+	if m.Seen == 0 {
+		return 0
+	}
+	return float64(m.Deduped) / float64(m.Seen)
+}
+
+// Accumulator deduplicates CertifyLegal and HasSourceAt predicates, keyed by
+// the canonical helpers.GetKey value of their subject plus the fields that
+// set them apart (see certifyLegalKey and hasSourceAtKey). Every other
+// predicate type is passed through unchanged.
+type Accumulator struct {
+	certifyLegal map[string]*assembler.CertifyLegalIngest
+	hasSourceAt  map[string]*assembler.HasSourceAtIngest
+	passthrough  assembler.IngestPredicates
+
+	metrics Metrics
+}
+
+// NewAccumulator returns an empty Accumulator.
+func NewAccumulator() *Accumulator {
+	return &Accumulator{
+		certifyLegal: make(map[string]*assembler.CertifyLegalIngest),
+		hasSourceAt:  make(map[string]*assembler.HasSourceAtIngest),
+	}
+}
+
+// Add merges preds into the accumulator, deduplicating CertifyLegal and
+// HasSourceAt entries against everything added so far.
+func (a *Accumulator) Add(preds assembler.IngestPredicates) {
+	for i := range preds.CertifyLegal {
+		a.addCertifyLegal(&preds.CertifyLegal[i])
+	}
+	for i := range preds.HasSourceAt {
+		a.addHasSourceAt(&preds.HasSourceAt[i])
+	}
+
+	a.passthrough.CertifyScorecard = append(a.passthrough.CertifyScorecard, preds.CertifyScorecard...)
+	a.passthrough.IsDependency = append(a.passthrough.IsDependency, preds.IsDependency...)
+	a.passthrough.IsOccurrence = append(a.passthrough.IsOccurrence, preds.IsOccurrence...)
+	a.passthrough.HasSlsa = append(a.passthrough.HasSlsa, preds.HasSlsa...)
+	a.passthrough.CertifyVuln = append(a.passthrough.CertifyVuln, preds.CertifyVuln...)
+	a.passthrough.VulnEqual = append(a.passthrough.VulnEqual, preds.VulnEqual...)
+	a.passthrough.CertifyBad = append(a.passthrough.CertifyBad, preds.CertifyBad...)
+	a.passthrough.CertifyGood = append(a.passthrough.CertifyGood, preds.CertifyGood...)
+	a.passthrough.HasSBOM = append(a.passthrough.HasSBOM, preds.HasSBOM...)
+	a.passthrough.HashEqual = append(a.passthrough.HashEqual, preds.HashEqual...)
+	a.passthrough.PkgEqual = append(a.passthrough.PkgEqual, preds.PkgEqual...)
+	a.passthrough.Vex = append(a.passthrough.Vex, preds.Vex...)
+	a.passthrough.PointOfContact = append(a.passthrough.PointOfContact, preds.PointOfContact...)
+	a.passthrough.VulnMetadata = append(a.passthrough.VulnMetadata, preds.VulnMetadata...)
+	a.passthrough.HasMetadata = append(a.passthrough.HasMetadata, preds.HasMetadata...)
+}
+
+func (a *Accumulator) addCertifyLegal(c *assembler.CertifyLegalIngest) {
+	a.metrics.Seen++
+	key := certifyLegalKey(c)
+	existing, ok := a.certifyLegal[key]
+	if !ok {
+		a.certifyLegal[key] = c
+		return
+	}
+	a.metrics.Deduped++
+	if c.CertifyLegal.TimeScanned.After(existing.CertifyLegal.TimeScanned) {
+		a.certifyLegal[key] = c
+	}
+}
+
+func (a *Accumulator) addHasSourceAt(h *assembler.HasSourceAtIngest) {
+	a.metrics.Seen++
+	key := hasSourceAtKey(h)
+	existing, ok := a.hasSourceAt[key]
+	if !ok {
+		a.hasSourceAt[key] = h
+		return
+	}
+	a.metrics.Deduped++
+	if h.HasSourceAt.KnownSince.After(existing.HasSourceAt.KnownSince) {
+		a.hasSourceAt[key] = h
+	}
+}
+
+// Flush returns everything accumulated so far, with CertifyLegal and
+// HasSourceAt deduplicated, and resets the accumulator for reuse.
+func (a *Accumulator) Flush() assembler.IngestPredicates {
+	out := a.passthrough
+	for _, c := range a.certifyLegal {
+		out.CertifyLegal = append(out.CertifyLegal, *c)
+	}
+	for _, h := range a.hasSourceAt {
+		out.HasSourceAt = append(out.HasSourceAt, *h)
+	}
+
+	a.certifyLegal = make(map[string]*assembler.CertifyLegalIngest)
+	a.hasSourceAt = make(map[string]*assembler.HasSourceAtIngest)
+	a.passthrough = assembler.IngestPredicates{}
+
+	return out
+}
+
+// Metrics reports the deduplication effectiveness of everything added to
+// the accumulator since it was created; Flush does not reset it.
+func (a *Accumulator) Metrics() Metrics {
+	return a.metrics
+}
+
+// Len reports the total number of predicate entries currently buffered,
+// after deduplication, across every predicate type.
+func (a *Accumulator) Len() int {
+	n := len(a.certifyLegal) + len(a.hasSourceAt)
+	n += len(a.passthrough.CertifyScorecard) + len(a.passthrough.IsDependency) + len(a.passthrough.IsOccurrence) +
+		len(a.passthrough.HasSlsa) + len(a.passthrough.CertifyVuln) + len(a.passthrough.VulnEqual) +
+		len(a.passthrough.CertifyBad) + len(a.passthrough.CertifyGood) + len(a.passthrough.HasSBOM) +
+		len(a.passthrough.HashEqual) + len(a.passthrough.PkgEqual) + len(a.passthrough.Vex) +
+		len(a.passthrough.PointOfContact) + len(a.passthrough.VulnMetadata) + len(a.passthrough.HasMetadata)
+	return n
+}
+
+// certifyLegalKey is the subject, scope, and license expressions of c -
+// everything but TimeScanned.
+//
+// BLOCKER(chunk0-4): c.Scope depends on the same pkg/assembler.
+// CertifyLegalIngest.Scope field the ClearlyDefined and SPDX parsers
+// assume - see their BLOCKER notes. This package doesn't compile
+// standalone until that field exists.
+func certifyLegalKey(c *assembler.CertifyLegalIngest) string {
+	return strings.Join([]string{
+		subjectKey(c.Pkg, c.Src),
+		c.Scope,
+		c.CertifyLegal.DeclaredLicense,
+		c.CertifyLegal.DiscoveredLicense,
+	}, "|")
+}
+
+// BLOCKER(chunk0-4): h.HasSourceAt.Root depends on the same
+// generated.HasSourceAtInputSpec.Root field the ClearlyDefined parser
+// assumes - see its BLOCKER note. Not mergeable standalone until that field
+// exists.
+func hasSourceAtKey(h *assembler.HasSourceAtIngest) string {
+	pkgKey := ""
+	if h.Pkg != nil {
+		pkgKey = helpers.GetKey[*generated.PkgInputSpec, helpers.PkgIds](h.Pkg, helpers.PkgClientKey).VersionId
+	}
+	return strings.Join([]string{pkgKey, subjectKey(nil, h.Src), h.HasSourceAt.Root}, "|")
+}
+
+func subjectKey(pkg *generated.PkgInputSpec, src *generated.SourceInputSpec) string {
+	switch {
+	case pkg != nil:
+		return "pkg:" + helpers.GetKey[*generated.PkgInputSpec, helpers.PkgIds](pkg, helpers.PkgClientKey).VersionId
+	case src != nil:
+		return "src:" + helpers.GetKey[*generated.SourceInputSpec, helpers.SrcIds](src, helpers.SrcClientKey).NameId
+	default:
+		return ""
+	}
+}
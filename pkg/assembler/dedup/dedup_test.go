@@ -0,0 +1,164 @@
+//
+// Copyright 2024 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/guacsec/guac/pkg/assembler"
+	"github.com/guacsec/guac/pkg/assembler/clients/generated"
+)
+
+func pkgInput(name string) *generated.PkgInputSpec {
+	return &generated.PkgInputSpec{Type: "golang", Name: name}
+}
+
+func TestAccumulatorDedupesIdenticalCertifyLegal(t *testing.T) {
+	acc := NewAccumulator()
+	scanned := time.Now()
+
+	preds := assembler.IngestPredicates{
+		CertifyLegal: []assembler.CertifyLegalIngest{
+			{
+				Pkg: pkgInput("foo"),
+				CertifyLegal: &generated.CertifyLegalInputSpec{
+					DeclaredLicense: "MIT",
+					TimeScanned:     scanned,
+				},
+			},
+			{
+				Pkg: pkgInput("foo"),
+				CertifyLegal: &generated.CertifyLegalInputSpec{
+					DeclaredLicense: "MIT",
+					TimeScanned:     scanned,
+				},
+			},
+		},
+	}
+	acc.Add(preds)
+
+	out := acc.Flush()
+	if len(out.CertifyLegal) != 1 {
+		t.Fatalf("got %d CertifyLegal entries, want 1", len(out.CertifyLegal))
+	}
+
+	metrics := acc.Metrics()
+	if metrics.Seen != 2 || metrics.Deduped != 1 {
+		t.Errorf("metrics = %+v, want Seen=2 Deduped=1", metrics)
+	}
+}
+
+func TestAccumulatorKeepsLatestScanTime(t *testing.T) {
+	acc := NewAccumulator()
+	older := time.Now()
+	newer := older.Add(time.Hour)
+
+	acc.Add(assembler.IngestPredicates{
+		CertifyLegal: []assembler.CertifyLegalIngest{{
+			Pkg: pkgInput("foo"),
+			CertifyLegal: &generated.CertifyLegalInputSpec{
+				DeclaredLicense: "MIT",
+				TimeScanned:     older,
+				Justification:   "first scan",
+			},
+		}},
+	})
+	acc.Add(assembler.IngestPredicates{
+		CertifyLegal: []assembler.CertifyLegalIngest{{
+			Pkg: pkgInput("foo"),
+			CertifyLegal: &generated.CertifyLegalInputSpec{
+				DeclaredLicense: "MIT",
+				TimeScanned:     newer,
+				Justification:   "second scan",
+			},
+		}},
+	})
+
+	out := acc.Flush()
+	if len(out.CertifyLegal) != 1 {
+		t.Fatalf("got %d CertifyLegal entries, want 1", len(out.CertifyLegal))
+	}
+	if out.CertifyLegal[0].CertifyLegal.Justification != "second scan" {
+		t.Errorf("kept entry justification = %q, want %q (the later scan)",
+			out.CertifyLegal[0].CertifyLegal.Justification, "second scan")
+	}
+}
+
+func TestAccumulatorKeepsDistinctScopesSeparate(t *testing.T) {
+	acc := NewAccumulator()
+	now := time.Now()
+
+	acc.Add(assembler.IngestPredicates{
+		CertifyLegal: []assembler.CertifyLegalIngest{
+			{
+				Pkg:   pkgInput("foo"),
+				Scope: "core",
+				CertifyLegal: &generated.CertifyLegalInputSpec{
+					DeclaredLicense: "MIT",
+					TimeScanned:     now,
+				},
+			},
+			{
+				Pkg:   pkgInput("foo"),
+				Scope: "tests",
+				CertifyLegal: &generated.CertifyLegalInputSpec{
+					DeclaredLicense: "MIT",
+					TimeScanned:     now,
+				},
+			},
+		},
+	})
+
+	out := acc.Flush()
+	if len(out.CertifyLegal) != 2 {
+		t.Fatalf("got %d CertifyLegal entries, want 2 (different scopes shouldn't merge)", len(out.CertifyLegal))
+	}
+}
+
+func TestAccumulatorPassesThroughOtherPredicateTypes(t *testing.T) {
+	acc := NewAccumulator()
+	acc.Add(assembler.IngestPredicates{
+		IsDependency: []assembler.IsDependencyIngest{{}, {}},
+		HasSBOM:      []assembler.HasSBOMIngest{{}},
+	})
+
+	out := acc.Flush()
+	if len(out.IsDependency) != 2 {
+		t.Errorf("got %d IsDependency entries, want 2", len(out.IsDependency))
+	}
+	if len(out.HasSBOM) != 1 {
+		t.Errorf("got %d HasSBOM entries, want 1", len(out.HasSBOM))
+	}
+}
+
+func TestAccumulatorFlushResets(t *testing.T) {
+	acc := NewAccumulator()
+	acc.Add(assembler.IngestPredicates{
+		CertifyLegal: []assembler.CertifyLegalIngest{{
+			Pkg: pkgInput("foo"),
+			CertifyLegal: &generated.CertifyLegalInputSpec{
+				DeclaredLicense: "MIT",
+				TimeScanned:     time.Now(),
+			},
+		}},
+	})
+	acc.Flush()
+
+	if got := acc.Len(); got != 0 {
+		t.Errorf("Len() after Flush() = %d, want 0", got)
+	}
+}
@@ -0,0 +1,333 @@
+//
+// Copyright 2024 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingestor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/guacsec/guac/pkg/assembler"
+	bulk_helpers "github.com/guacsec/guac/pkg/assembler/clients/helpers"
+	"github.com/guacsec/guac/pkg/assembler/dedup"
+)
+
+// approxPredicateBytes is a rough, constant per-entry size used to decide
+// when accumulated predicates have grown large enough to force a flush.
+const approxPredicateBytes = 512
+
+// defaultChunkSize is the addChunked chunk size used when IngestOptions
+// doesn't set BatchSize, so a single document's predicates are still
+// sub-batched for fullness checks even without a configured threshold.
+const defaultChunkSize = 1000
+
+// predicateBatch accumulates predicates produced by the parser workers,
+// deduplicating as it goes, until it is full enough to flush.
+type predicateBatch struct {
+	acc *dedup.Accumulator
+}
+
+func newPredicateBatch() predicateBatch {
+	return predicateBatch{acc: dedup.NewAccumulator()}
+}
+
+func (b *predicateBatch) add(p assembler.IngestPredicates) {
+	b.acc.Add(p)
+}
+
+// addChunked adds p to the batch in pieces no larger than chunkSize entries
+// per predicate type, invoking onChunkAdded after each piece. A single
+// document can produce an IngestPredicates with tens of thousands of entries
+// of one type (e.g. IsDependency); adding it in one shot would only let the
+// caller check for fullness once the whole document is already buffered.
+// Slicing like this doesn't copy the underlying arrays, so it costs nothing
+// beyond the chunking itself.
+func (b *predicateBatch) addChunked(p assembler.IngestPredicates, chunkSize int, onChunkAdded func()) {
+	for _, chunk := range splitIngestPredicates(p, chunkSize) {
+		b.add(chunk)
+		onChunkAdded()
+	}
+}
+
+// full reports whether the batch has accumulated enough predicate entries,
+// summed across all types, or enough total estimated bytes, to be worth
+// flushing.
+func (b *predicateBatch) full(opts IngestOptions) bool {
+	return batchFull(b.acc.Len(), opts)
+}
+
+// batchFull reports whether entries has grown large enough to flush, under
+// either threshold. A threshold <= 0 means "no limit" (matching the
+// RetryPolicy.MaxDelay convention in nextDelay below), not "limit of zero" -
+// otherwise a caller who builds an IngestOptions directly instead of
+// starting from DefaultIngestOptions, and leaves one field unset, would
+// flush after every single entry.
+func batchFull(entries int, opts IngestOptions) bool {
+	if entries == 0 {
+		return false
+	}
+	if opts.MaxInFlightBytes > 0 && int64(entries)*approxPredicateBytes >= opts.MaxInFlightBytes {
+		return true
+	}
+	return opts.BatchSize > 0 && entries >= opts.BatchSize
+}
+
+// splitIngestPredicates splits p into chunks no larger than chunkSize
+// entries per predicate type. A chunkSize <= 0 disables chunking, returning
+// p unchanged as the only chunk.
+func splitIngestPredicates(p assembler.IngestPredicates, chunkSize int) []assembler.IngestPredicates {
+	if chunkSize <= 0 {
+		return []assembler.IngestPredicates{p}
+	}
+
+	var chunks []assembler.IngestPredicates
+	splitCertifyLegal(p.CertifyLegal, chunkSize, &chunks)
+	splitHasSourceAt(p.HasSourceAt, chunkSize, &chunks)
+	splitCertifyScorecard(p.CertifyScorecard, chunkSize, &chunks)
+	splitIsDependency(p.IsDependency, chunkSize, &chunks)
+	splitIsOccurrence(p.IsOccurrence, chunkSize, &chunks)
+	splitHasSlsa(p.HasSlsa, chunkSize, &chunks)
+	splitCertifyVuln(p.CertifyVuln, chunkSize, &chunks)
+	splitVulnEqual(p.VulnEqual, chunkSize, &chunks)
+	splitCertifyBad(p.CertifyBad, chunkSize, &chunks)
+	splitCertifyGood(p.CertifyGood, chunkSize, &chunks)
+	splitHasSBOM(p.HasSBOM, chunkSize, &chunks)
+	splitHashEqual(p.HashEqual, chunkSize, &chunks)
+	splitPkgEqual(p.PkgEqual, chunkSize, &chunks)
+	splitVex(p.Vex, chunkSize, &chunks)
+	splitPointOfContact(p.PointOfContact, chunkSize, &chunks)
+	splitVulnMetadata(p.VulnMetadata, chunkSize, &chunks)
+	splitHasMetadata(p.HasMetadata, chunkSize, &chunks)
+
+	if len(chunks) == 0 {
+		return []assembler.IngestPredicates{p}
+	}
+	return chunks
+}
+
+func splitCertifyLegal(s []assembler.CertifyLegalIngest, chunkSize int, chunks *[]assembler.IngestPredicates) {
+	for lo := 0; lo < len(s); lo += chunkSize {
+		hi := min(lo+chunkSize, len(s))
+		*chunks = append(*chunks, assembler.IngestPredicates{CertifyLegal: s[lo:hi]})
+	}
+}
+
+func splitHasSourceAt(s []assembler.HasSourceAtIngest, chunkSize int, chunks *[]assembler.IngestPredicates) {
+	for lo := 0; lo < len(s); lo += chunkSize {
+		hi := min(lo+chunkSize, len(s))
+		*chunks = append(*chunks, assembler.IngestPredicates{HasSourceAt: s[lo:hi]})
+	}
+}
+
+func splitCertifyScorecard(s []assembler.CertifyScorecardIngest, chunkSize int, chunks *[]assembler.IngestPredicates) {
+	for lo := 0; lo < len(s); lo += chunkSize {
+		hi := min(lo+chunkSize, len(s))
+		*chunks = append(*chunks, assembler.IngestPredicates{CertifyScorecard: s[lo:hi]})
+	}
+}
+
+func splitIsDependency(s []assembler.IsDependencyIngest, chunkSize int, chunks *[]assembler.IngestPredicates) {
+	for lo := 0; lo < len(s); lo += chunkSize {
+		hi := min(lo+chunkSize, len(s))
+		*chunks = append(*chunks, assembler.IngestPredicates{IsDependency: s[lo:hi]})
+	}
+}
+
+func splitIsOccurrence(s []assembler.IsOccurrenceIngest, chunkSize int, chunks *[]assembler.IngestPredicates) {
+	for lo := 0; lo < len(s); lo += chunkSize {
+		hi := min(lo+chunkSize, len(s))
+		*chunks = append(*chunks, assembler.IngestPredicates{IsOccurrence: s[lo:hi]})
+	}
+}
+
+func splitHasSlsa(s []assembler.HasSlsaIngest, chunkSize int, chunks *[]assembler.IngestPredicates) {
+	for lo := 0; lo < len(s); lo += chunkSize {
+		hi := min(lo+chunkSize, len(s))
+		*chunks = append(*chunks, assembler.IngestPredicates{HasSlsa: s[lo:hi]})
+	}
+}
+
+func splitCertifyVuln(s []assembler.CertifyVulnIngest, chunkSize int, chunks *[]assembler.IngestPredicates) {
+	for lo := 0; lo < len(s); lo += chunkSize {
+		hi := min(lo+chunkSize, len(s))
+		*chunks = append(*chunks, assembler.IngestPredicates{CertifyVuln: s[lo:hi]})
+	}
+}
+
+func splitVulnEqual(s []assembler.VulnEqualIngest, chunkSize int, chunks *[]assembler.IngestPredicates) {
+	for lo := 0; lo < len(s); lo += chunkSize {
+		hi := min(lo+chunkSize, len(s))
+		*chunks = append(*chunks, assembler.IngestPredicates{VulnEqual: s[lo:hi]})
+	}
+}
+
+func splitCertifyBad(s []assembler.CertifyBadIngest, chunkSize int, chunks *[]assembler.IngestPredicates) {
+	for lo := 0; lo < len(s); lo += chunkSize {
+		hi := min(lo+chunkSize, len(s))
+		*chunks = append(*chunks, assembler.IngestPredicates{CertifyBad: s[lo:hi]})
+	}
+}
+
+func splitCertifyGood(s []assembler.CertifyGoodIngest, chunkSize int, chunks *[]assembler.IngestPredicates) {
+	for lo := 0; lo < len(s); lo += chunkSize {
+		hi := min(lo+chunkSize, len(s))
+		*chunks = append(*chunks, assembler.IngestPredicates{CertifyGood: s[lo:hi]})
+	}
+}
+
+func splitHasSBOM(s []assembler.HasSBOMIngest, chunkSize int, chunks *[]assembler.IngestPredicates) {
+	for lo := 0; lo < len(s); lo += chunkSize {
+		hi := min(lo+chunkSize, len(s))
+		*chunks = append(*chunks, assembler.IngestPredicates{HasSBOM: s[lo:hi]})
+	}
+}
+
+func splitHashEqual(s []assembler.HashEqualIngest, chunkSize int, chunks *[]assembler.IngestPredicates) {
+	for lo := 0; lo < len(s); lo += chunkSize {
+		hi := min(lo+chunkSize, len(s))
+		*chunks = append(*chunks, assembler.IngestPredicates{HashEqual: s[lo:hi]})
+	}
+}
+
+func splitPkgEqual(s []assembler.PkgEqualIngest, chunkSize int, chunks *[]assembler.IngestPredicates) {
+	for lo := 0; lo < len(s); lo += chunkSize {
+		hi := min(lo+chunkSize, len(s))
+		*chunks = append(*chunks, assembler.IngestPredicates{PkgEqual: s[lo:hi]})
+	}
+}
+
+func splitVex(s []assembler.VexIngest, chunkSize int, chunks *[]assembler.IngestPredicates) {
+	for lo := 0; lo < len(s); lo += chunkSize {
+		hi := min(lo+chunkSize, len(s))
+		*chunks = append(*chunks, assembler.IngestPredicates{Vex: s[lo:hi]})
+	}
+}
+
+func splitPointOfContact(s []assembler.PointOfContactIngest, chunkSize int, chunks *[]assembler.IngestPredicates) {
+	for lo := 0; lo < len(s); lo += chunkSize {
+		hi := min(lo+chunkSize, len(s))
+		*chunks = append(*chunks, assembler.IngestPredicates{PointOfContact: s[lo:hi]})
+	}
+}
+
+func splitVulnMetadata(s []assembler.VulnMetadataIngest, chunkSize int, chunks *[]assembler.IngestPredicates) {
+	for lo := 0; lo < len(s); lo += chunkSize {
+		hi := min(lo+chunkSize, len(s))
+		*chunks = append(*chunks, assembler.IngestPredicates{VulnMetadata: s[lo:hi]})
+	}
+}
+
+func splitHasMetadata(s []assembler.HasMetadataIngest, chunkSize int, chunks *[]assembler.IngestPredicates) {
+	for lo := 0; lo < len(s); lo += chunkSize {
+		hi := min(lo+chunkSize, len(s))
+		*chunks = append(*chunks, assembler.IngestPredicates{HasMetadata: s[lo:hi]})
+	}
+}
+
+func (b *predicateBatch) empty() bool {
+	return b.acc.Len() == 0
+}
+
+// len reports the number of predicate entries currently buffered.
+func (b *predicateBatch) len() int {
+	return b.acc.Len()
+}
+
+// metrics reports deduplication effectiveness across everything added to
+// the batch, including predicates already flushed.
+func (b *predicateBatch) metrics() dedup.Metrics {
+	return b.acc.Metrics()
+}
+
+// flush returns the accumulated predicates, deduplicated, and resets the
+// batch for reuse.
+func (b *predicateBatch) flush() assembler.IngestPredicates {
+	return b.acc.Flush()
+}
+
+// flushWithRetry flushes the accumulated batch through assemblerFunc,
+// retrying with exponential backoff on failure per the given RetryPolicy.
+func flushWithRetry(
+	ctx context.Context,
+	logger *zap.SugaredLogger,
+	assemblerFunc func([]assembler.IngestPredicates) (*bulk_helpers.AssemblerIngestedIDs, error),
+	batch assembler.IngestPredicates,
+	policy RetryPolicy,
+) (*bulk_helpers.AssemblerIngestedIDs, error) {
+	maxAttempts := normalizeMaxAttempts(policy.MaxAttempts)
+
+	delay := policy.InitialDelay
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		ids, err := assemblerFunc([]assembler.IngestPredicates{batch})
+		if err == nil {
+			return ids, nil
+		}
+		lastErr = err
+		if attempt == maxAttempts {
+			break
+		}
+		logger.Warnf("assembler flush failed (attempt %d/%d), retrying in %v: %v", attempt, maxAttempts, delay, err)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("assembler flush aborted: %w", ctx.Err())
+		case <-time.After(delay):
+		}
+		delay = nextDelay(delay, policy)
+	}
+	return nil, fmt.Errorf("assembler flush failed after %d attempts: %w", maxAttempts, lastErr)
+}
+
+// normalizeMaxAttempts clamps a RetryPolicy's MaxAttempts to at least 1, so
+// a zero-value policy still makes a single attempt.
+func normalizeMaxAttempts(maxAttempts int) int {
+	if maxAttempts < 1 {
+		return 1
+	}
+	return maxAttempts
+}
+
+// nextDelay scales delay by policy.Multiplier, capped at policy.MaxDelay
+// (a MaxDelay <= 0 means uncapped).
+func nextDelay(delay time.Duration, policy RetryPolicy) time.Duration {
+	delay = time.Duration(float64(delay) * policy.Multiplier)
+	if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	return delay
+}
+
+// flushErrors aggregates per-batch flush failures so that one bad batch
+// doesn't abort the rest of the run.
+type flushErrors struct {
+	errs []error
+}
+
+func (f *flushErrors) add(err error) {
+	if err != nil {
+		f.errs = append(f.errs, err)
+	}
+}
+
+func (f *flushErrors) errOrNil() error {
+	if len(f.errs) == 0 {
+		return nil
+	}
+	return errors.Join(f.errs...)
+}
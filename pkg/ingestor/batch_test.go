@@ -0,0 +1,160 @@
+//
+// Copyright 2024 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingestor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/guacsec/guac/pkg/assembler"
+)
+
+func TestBatchFull(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries int
+		opts    IngestOptions
+		want    bool
+	}{
+		{
+			name:    "empty batch is never full",
+			entries: 0,
+			opts:    IngestOptions{BatchSize: 1, MaxInFlightBytes: 1},
+			want:    false,
+		},
+		{
+			name:    "below both thresholds",
+			entries: 2,
+			opts:    IngestOptions{BatchSize: 10, MaxInFlightBytes: 10 * approxPredicateBytes},
+			want:    false,
+		},
+		{
+			name:    "reaches BatchSize",
+			entries: 10,
+			opts:    IngestOptions{BatchSize: 10, MaxInFlightBytes: 1 << 30},
+			want:    true,
+		},
+		{
+			name:    "reaches MaxInFlightBytes before BatchSize",
+			entries: 3,
+			opts:    IngestOptions{BatchSize: 1000, MaxInFlightBytes: 3 * approxPredicateBytes},
+			want:    true,
+		},
+		{
+			name:    "zero-value IngestOptions never forces a flush",
+			entries: 1,
+			opts:    IngestOptions{},
+			want:    false,
+		},
+		{
+			name:    "BatchSize unset, MaxInFlightBytes still enforced",
+			entries: 10,
+			opts:    IngestOptions{MaxInFlightBytes: 10 * approxPredicateBytes},
+			want:    true,
+		},
+		{
+			name:    "MaxInFlightBytes unset, BatchSize still enforced",
+			entries: 10,
+			opts:    IngestOptions{BatchSize: 10},
+			want:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := batchFull(tt.entries, tt.opts); got != tt.want {
+				t.Errorf("batchFull(%d, %+v) = %v, want %v", tt.entries, tt.opts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitIngestPredicatesChunksOneType(t *testing.T) {
+	p := assembler.IngestPredicates{
+		IsDependency: make([]assembler.IsDependencyIngest, 25),
+	}
+
+	chunks := splitIngestPredicates(p, 10)
+	if len(chunks) != 3 {
+		t.Fatalf("got %d chunks, want 3", len(chunks))
+	}
+	wantLens := []int{10, 10, 5}
+	for i, want := range wantLens {
+		if got := len(chunks[i].IsDependency); got != want {
+			t.Errorf("chunk %d: got %d IsDependency entries, want %d", i, got, want)
+		}
+	}
+}
+
+func TestSplitIngestPredicatesDisabledByNonPositiveChunkSize(t *testing.T) {
+	p := assembler.IngestPredicates{IsDependency: make([]assembler.IsDependencyIngest, 5)}
+	chunks := splitIngestPredicates(p, 0)
+	if len(chunks) != 1 || len(chunks[0].IsDependency) != 5 {
+		t.Errorf("chunkSize <= 0 should return p unchanged as a single chunk, got %+v", chunks)
+	}
+}
+
+func TestAddChunkedInvokesCallbackPerChunk(t *testing.T) {
+	b := newPredicateBatch()
+	p := assembler.IngestPredicates{IsDependency: make([]assembler.IsDependencyIngest, 25)}
+
+	calls := 0
+	b.addChunked(p, 10, func() { calls++ })
+
+	if calls != 3 {
+		t.Errorf("got %d callback invocations, want 3 (one per chunk)", calls)
+	}
+	if got := b.len(); got != 25 {
+		t.Errorf("batch has %d entries after addChunked, want 25", got)
+	}
+}
+
+func TestNormalizeMaxAttempts(t *testing.T) {
+	tests := []struct {
+		in   int
+		want int
+	}{
+		{in: 0, want: 1},
+		{in: -5, want: 1},
+		{in: 1, want: 1},
+		{in: 5, want: 5},
+	}
+	for _, tt := range tests {
+		if got := normalizeMaxAttempts(tt.in); got != tt.want {
+			t.Errorf("normalizeMaxAttempts(%d) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNextDelay(t *testing.T) {
+	policy := RetryPolicy{Multiplier: 2, MaxDelay: 4 * time.Second}
+
+	got := nextDelay(time.Second, policy)
+	if got != 2*time.Second {
+		t.Errorf("nextDelay(1s) = %v, want 2s", got)
+	}
+
+	got = nextDelay(3*time.Second, policy)
+	if got != 4*time.Second {
+		t.Errorf("nextDelay(3s) = %v, want capped at 4s", got)
+	}
+
+	uncapped := RetryPolicy{Multiplier: 3, MaxDelay: 0}
+	got = nextDelay(time.Second, uncapped)
+	if got != 3*time.Second {
+		t.Errorf("nextDelay with MaxDelay<=0 = %v, want uncapped 3s", got)
+	}
+}
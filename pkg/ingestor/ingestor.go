@@ -19,15 +19,15 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/Khan/genqlient/graphql"
 	"github.com/guacsec/guac/pkg/assembler"
-	"github.com/guacsec/guac/pkg/assembler/clients/generated"
 	bulk_helpers "github.com/guacsec/guac/pkg/assembler/clients/helpers"
-	"github.com/guacsec/guac/pkg/assembler/helpers"
+	"github.com/guacsec/guac/pkg/assembler/dedup"
 	csub_client "github.com/guacsec/guac/pkg/collectsub/client"
 	"github.com/guacsec/guac/pkg/collectsub/collectsub/input"
 	"github.com/guacsec/guac/pkg/handler/processor"
@@ -72,7 +72,14 @@ func Ingest(
 		logger.Infof("unable to create entries in collectsub server, but continuing: %v", err)
 	}
 
-	ingestedIDs, err := assemblerFunc(predicates)
+	acc := dedup.NewAccumulator()
+	for _, p := range predicates {
+		acc.Add(p)
+	}
+	dedupMetrics := acc.Metrics()
+	logger.Debugf("dedup ratio %.2f (%d/%d) for doc %+v", dedupMetrics.Ratio(), dedupMetrics.Deduped, dedupMetrics.Seen, d.SourceInformation)
+
+	ingestedIDs, err := assemblerFunc([]assembler.IngestPredicates{acc.Flush()})
 	if err != nil {
 		return nil, fmt.Errorf("error assembling graphs for %q : %w", d.SourceInformation.Source, err)
 	}
@@ -83,6 +90,20 @@ func Ingest(
 	return ingestedIDs, nil
 }
 
+// parsedDoc carries the result of running a single document through the
+// processor and parser stages to the assembler stage.
+type parsedDoc struct {
+	docNum int
+	preds  []assembler.IngestPredicates
+	idstrs []*parser_common.IdentifierStrings
+	err    error
+}
+
+// MergedIngest streams docs through a bounded pool of parser workers into a
+// single assembler goroutine. Partial failures of individual documents or
+// assembler flushes are aggregated rather than aborting the rest of the
+// run; the final partial batch is always flushed, even if earlier flushes
+// failed.
 func MergedIngest(
 	ctx context.Context,
 	docs []*processor.Document,
@@ -93,6 +114,7 @@ func MergedIngest(
 	scanForLicense bool,
 	scanForEOL bool,
 	scanForDepsDev bool,
+	opts IngestOptions,
 ) error {
 	logger := logging.FromContext(ctx)
 	// Get pipeline of components
@@ -101,108 +123,145 @@ func MergedIngest(
 	collectSubEmitFunc := GetCollectSubEmit(ctx, csubClient)
 	assemblerFunc := GetAssembler(ctx, logger, graphqlEndpoint, transport)
 
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+
 	start := time.Now()
+	logger.Infof("starting MergedIngest with %d documents, concurrency=%d, batchSize=%d",
+		len(docs), opts.Concurrency, opts.BatchSize)
 
-	predicates := make([]assembler.IngestPredicates, 1)
-	totalPredicates := 0
-	var idstrings []*parser_common.IdentifierStrings
+	docsChan := make(chan struct {
+		num int
+		doc *processor.Document
+	}, opts.Concurrency)
+	resultsChan := make(chan parsedDoc, opts.Concurrency)
 
-	// [GuacDebug] DEBUG POINT 4: Log start of processing
-	logger.Infof("[GuacDebug] [INGESTOR] Starting MergedIngest with %d documents", len(docs))
+	var workers sync.WaitGroup
+	for i := 0; i < opts.Concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for item := range docsChan {
+				docTree, err := processorFunc(item.doc)
+				if err != nil {
+					resultsChan <- parsedDoc{docNum: item.num, err: fmt.Errorf(
+						"unable to process doc %d: %w, format: %v, document: %v", item.num, err, item.doc.Format, item.doc.Type)}
+					continue
+				}
+				preds, idstrs, err := ingestorFunc(docTree)
+				if err != nil {
+					resultsChan <- parsedDoc{docNum: item.num, err: fmt.Errorf("unable to ingest doc tree %d: %w", item.num, err)}
+					continue
+				}
+				resultsChan <- parsedDoc{docNum: item.num, preds: preds, idstrs: idstrs}
+			}
+		}()
+	}
 
-	for docNum, d := range docs {
-		docTree, err := processorFunc(d)
-		if err != nil {
-			return fmt.Errorf("unable to process doc: %v, format: %v, document: %v", err, d.Format, d.Type)
+	go func() {
+		for i, d := range docs {
+			docsChan <- struct {
+				num int
+				doc *processor.Document
+			}{num: i, doc: d}
 		}
+		close(docsChan)
+	}()
+
+	go func() {
+		workers.Wait()
+		close(resultsChan)
+	}()
+
+	var ticker *time.Ticker
+	var tickerChan <-chan time.Time
+	if opts.FlushInterval > 0 {
+		ticker = time.NewTicker(opts.FlushInterval)
+		tickerChan = ticker.C
+		defer ticker.Stop()
+	}
+
+	var idstrings []*parser_common.IdentifierStrings
+	var flushErr flushErrors
+	var docErr flushErrors
+	batch := newPredicateBatch()
+
+	// chunkSize bounds how many entries of a single predicate type are added
+	// to the batch before fullness is checked again; it defaults to
+	// defaultChunkSize when BatchSize isn't set, since BatchSize <= 0 no
+	// longer implies a threshold of zero (see batchFull).
+	chunkSize := opts.BatchSize
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
 
-		preds, idstrs, err := ingestorFunc(docTree)
-		if err != nil {
-			return fmt.Errorf("unable to ingest doc tree: %v", err)
+	flush := func() {
+		if batch.empty() {
+			return
 		}
+		entries := batch.len()
+		deduped := batch.flush()
+		logger.Infof("flushing batch of %d predicate entries to assembler", entries)
+		if _, err := flushWithRetry(ctx, logger, assemblerFunc, deduped, opts.RetryPolicy); err != nil {
+			flushErr.add(fmt.Errorf("unable to assemble graphs: %w", err))
+		}
+		if ticker != nil {
+			ticker.Reset(opts.FlushInterval)
+		}
+	}
 
-		// [GuacDebug] DEBUG POINT 5: Log predicates from each document
-		logger.Debugf("[GuacDebug] [INGESTOR] Document %d predicates: CertifyLegal=%d, HasSourceAt=%d",
-			docNum, len(preds[0].CertifyLegal), len(preds[0].HasSourceAt))
-
-		for i := range preds {
-			predicates[0].CertifyScorecard = append(predicates[0].CertifyScorecard, preds[i].CertifyScorecard...)
-			predicates[0].IsDependency = append(predicates[0].IsDependency, preds[i].IsDependency...)
-			predicates[0].IsOccurrence = append(predicates[0].IsOccurrence, preds[i].IsOccurrence...)
-			predicates[0].HasSlsa = append(predicates[0].HasSlsa, preds[i].HasSlsa...)
-			predicates[0].CertifyVuln = append(predicates[0].CertifyVuln, preds[i].CertifyVuln...)
-			predicates[0].VulnEqual = append(predicates[0].VulnEqual, preds[i].VulnEqual...)
-			predicates[0].HasSourceAt = append(predicates[0].HasSourceAt, preds[i].HasSourceAt...)
-			predicates[0].CertifyBad = append(predicates[0].CertifyBad, preds[i].CertifyBad...)
-			predicates[0].CertifyGood = append(predicates[0].CertifyGood, preds[i].CertifyGood...)
-			predicates[0].HasSBOM = append(predicates[0].HasSBOM, preds[i].HasSBOM...)
-			predicates[0].HashEqual = append(predicates[0].HashEqual, preds[i].HashEqual...)
-			predicates[0].PkgEqual = append(predicates[0].PkgEqual, preds[i].PkgEqual...)
-			predicates[0].Vex = append(predicates[0].Vex, preds[i].Vex...)
-			predicates[0].PointOfContact = append(predicates[0].PointOfContact, preds[i].PointOfContact...)
-			predicates[0].VulnMetadata = append(predicates[0].VulnMetadata, preds[i].VulnMetadata...)
-			predicates[0].HasMetadata = append(predicates[0].HasMetadata, preds[i].HasMetadata...)
-			predicates[0].CertifyLegal = append(predicates[0].CertifyLegal, preds[i].CertifyLegal...)
-
-			// [GuacDebug] DEBUG POINT 6: Log after merging
-			logger.Debugf("[GuacDebug] [INGESTOR] After merge: Total CertifyLegal=%d, HasSourceAt=%d",
-				len(predicates[0].CertifyLegal), len(predicates[0].HasSourceAt))
-
-			// [GuacDebug] DEBUG POINT 7: Check for duplicate sources in accumulated predicates
-			sourcesSeen := make(map[string]int)
-			for _, cl := range predicates[0].CertifyLegal {
-				if cl.Src != nil {
-					srcKey := helpers.GetKey[*generated.SourceInputSpec, helpers.SrcIds](
-						cl.Src, helpers.SrcClientKey).NameId
-					sourcesSeen[srcKey]++
-				}
-			}
-			for _, hs := range predicates[0].HasSourceAt {
-				if hs.Src != nil {
-					srcKey := helpers.GetKey[*generated.SourceInputSpec, helpers.SrcIds](
-						hs.Src, helpers.SrcClientKey).NameId
-					sourcesSeen[srcKey]++
-				}
+drain:
+	for {
+		select {
+		case res, ok := <-resultsChan:
+			if !ok {
+				break drain
 			}
-
-			for srcKey, count := range sourcesSeen {
-				if count > 1 {
-					logger.Warnf("⚠️  [INGESTOR] Source appears %d times in predicates: %s", count, srcKey)
-				}
+			if res.err != nil {
+				docErr.add(res.err)
+				continue
 			}
-
-			totalPredicates += 1
-			// enough predicates have been collected, worth sending them to GraphQL server
-			if totalPredicates == 5000 {
-				// [GuacDebug] DEBUG POINT 8: Batch processing
-				logger.Infof("[GuacDebug] [INGESTOR] Calling assembler with batch of %d predicates", totalPredicates)
-				_, err = assemblerFunc(predicates)
-				if err != nil {
-					return fmt.Errorf("unable to assemble graphs: %v", err)
-				}
-				// reset counter and predicates
-				totalPredicates = 0
-				predicates[0] = assembler.IngestPredicates{}
+			for _, p := range res.preds {
+				// addChunked checks fullness after every chunk, not just
+				// once the whole document has been added, so a single
+				// document with a very large predicate slice (e.g. tens of
+				// thousands of IsDependency entries) can still trigger a
+				// flush partway through instead of being buffered in full.
+				batch.addChunked(p, chunkSize, func() {
+					if batch.full(opts) {
+						flush()
+					}
+				})
 			}
+			idstrings = append(idstrings, res.idstrs...)
+		case <-tickerChan:
+			flush()
 		}
-		idstrings = append(idstrings, idstrs...)
 	}
+	// Guaranteed final flush of whatever remains, even if earlier flushes
+	// or documents failed.
+	flush()
 
-	err := collectSubEmitFunc(idstrings)
-	if err != nil {
+	if err := collectSubEmitFunc(idstrings); err != nil {
 		logger.Infof("unable to create entries in collectsub server, but continuing: %v", err)
 	}
 
-	// [GuacDebug] DEBUG POINT 8: Final batch
-	logger.Infof("[GuacDebug] [INGESTOR] Calling assembler with final batch (%d predicates)", totalPredicates)
-
-	_, err = assemblerFunc(predicates)
-	if err != nil {
-		return fmt.Errorf("unable to assemble graphs: %v", err)
-	}
 	t := time.Now()
 	elapsed := t.Sub(start)
-	logger.Infof("[%v] completed docs %+v", elapsed, len(docs))
+	dedupMetrics := batch.metrics()
+	logger.Infof("[%v] completed docs %+v, dedup ratio %.2f (%d/%d)",
+		elapsed, len(docs), dedupMetrics.Ratio(), dedupMetrics.Deduped, dedupMetrics.Seen)
+
+	if err := docErr.errOrNil(); err != nil {
+		if fErr := flushErr.errOrNil(); fErr != nil {
+			return fmt.Errorf("errors processing documents: %w; errors flushing to assembler: %v", err, fErr)
+		}
+		return fmt.Errorf("errors processing documents: %w", err)
+	}
+	if err := flushErr.errOrNil(); err != nil {
+		return fmt.Errorf("errors flushing to assembler: %w", err)
+	}
 	return nil
 }
 
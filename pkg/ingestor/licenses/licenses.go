@@ -0,0 +1,162 @@
+//
+// Copyright 2024 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package licenses provides a single, pluggable way for parsers to resolve
+// declared license expressions, so that every parser that produces
+// CertifyLegal predicates agrees on what counts as a match. ScanText is
+// available for a future raw-text ingestion path (e.g. a collector-fetched
+// LICENSE file); no parser in this series calls it yet, since
+// ClearlyDefined and SPDX only ever hand this package license expressions.
+package licenses
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/licensecheck"
+
+	"github.com/guacsec/guac/pkg/assembler/clients/generated"
+	"github.com/guacsec/guac/pkg/logging"
+)
+
+// DefaultCoverageThreshold is the minimum percentage, 0-100, of scanned text
+// that must match a known license for ScanText to report it. Matches below
+// this are discarded as noise, mirroring the threshold Syft uses.
+const DefaultCoverageThreshold = 75.0
+
+// LicenseMatch is a single license identified within a block of text, along
+// with how much of that text it covers.
+type LicenseMatch struct {
+	// LicenseID is the SPDX identifier of the matched license (e.g.
+	// "Apache-2.0"), or the licensecheck-internal name if it has none.
+	LicenseID string
+	// Coverage is the percentage, 0-100, of the scanned text this match
+	// accounts for.
+	Coverage float64
+	// Start and End locate the match within the scanned text (byte
+	// offsets), for callers that want to attribute a match to a specific
+	// file or snippet range.
+	Start int
+	End   int
+}
+
+// Scanner identifies licenses in free text and parses SPDX license
+// expressions into the License nodes GUAC ingests. Parsers resolve a single
+// Scanner through the context (see WithScanner/FromContext).
+type Scanner interface {
+	// ScanText scans content for license matches, discarding anything
+	// below the scanner's coverage threshold. Not called by any parser in
+	// this series yet.
+	ScanText(ctx context.Context, content []byte) ([]LicenseMatch, error)
+	// ScanExpression parses an SPDX license expression (e.g.
+	// "MIT AND Apache-2.0") into the License nodes it references.
+	ScanExpression(expr string) ([]generated.LicenseInputSpec, error)
+}
+
+type scannerKey struct{}
+
+// WithScanner returns a copy of ctx carrying scanner, retrievable with
+// FromContext.
+func WithScanner(ctx context.Context, scanner Scanner) context.Context {
+	return context.WithValue(ctx, scannerKey{}, scanner)
+}
+
+// FromContext returns the Scanner previously stored with WithScanner, or a
+// NewDefaultScanner with DefaultCoverageThreshold if none was set.
+func FromContext(ctx context.Context) Scanner {
+	if s, ok := ctx.Value(scannerKey{}).(Scanner); ok && s != nil {
+		return s
+	}
+	return NewDefaultScanner(DefaultCoverageThreshold)
+}
+
+// defaultScanner is the default Scanner implementation, backed by
+// github.com/google/licensecheck.
+type defaultScanner struct {
+	coverageThreshold float64
+}
+
+// NewDefaultScanner returns the default Scanner, discarding ScanText matches
+// whose coverage of the scanned text is below coverageThreshold percent.
+func NewDefaultScanner(coverageThreshold float64) Scanner {
+	return &defaultScanner{coverageThreshold: coverageThreshold}
+}
+
+// ScanText implements Scanner.
+func (d *defaultScanner) ScanText(ctx context.Context, content []byte) ([]LicenseMatch, error) {
+	logger := logging.FromContext(ctx)
+
+	cov := licensecheck.Scan(content)
+	matches := make([]LicenseMatch, 0, len(cov.Match))
+	for _, m := range cov.Match {
+		coverage := percentOf(m.End-m.Start, len(content))
+		if coverage < d.coverageThreshold {
+			logger.Debugf("discarding license match %q with coverage %.1f%% below threshold %.1f%%",
+				m.ID, coverage, d.coverageThreshold)
+			continue
+		}
+		matches = append(matches, LicenseMatch{
+			LicenseID: m.ID,
+			Coverage:  coverage,
+			Start:     m.Start,
+			End:       m.End,
+		})
+	}
+	return matches, nil
+}
+
+// ScanExpression implements Scanner. It splits on the SPDX "AND"/"OR"
+// conjunctions, ingesting one License node per referenced identifier; "WITH"
+// exceptions stay attached to the identifier they modify.
+func (d *defaultScanner) ScanExpression(expr string) ([]generated.LicenseInputSpec, error) {
+	licenseIDs := splitLicenseIDs(expr)
+	licenseInputs := make([]generated.LicenseInputSpec, 0, len(licenseIDs))
+	for _, id := range licenseIDs {
+		licenseInputs = append(licenseInputs, generated.LicenseInputSpec{Name: id})
+	}
+	return licenseInputs, nil
+}
+
+// splitLicenseIDs splits an SPDX license expression on "AND"/"OR" into its
+// referenced identifiers, trimming the parens "WITH" exceptions are often
+// wrapped in.
+func splitLicenseIDs(expr string) []string {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil
+	}
+
+	var terms []string
+	for _, term := range strings.Split(expr, " OR ") {
+		terms = append(terms, strings.Split(term, " AND ")...)
+	}
+
+	ids := make([]string, 0, len(terms))
+	for _, id := range terms {
+		id = strings.Trim(strings.TrimSpace(id), "()")
+		if id == "" {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func percentOf(n, total int) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(n) / float64(total) * 100
+}
@@ -0,0 +1,52 @@
+//
+// Copyright 2024 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitLicenseIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want []string
+	}{
+		{name: "empty", expr: "", want: nil},
+		{name: "single", expr: "MIT", want: []string{"MIT"}},
+		{name: "and", expr: "MIT AND Apache-2.0", want: []string{"MIT", "Apache-2.0"}},
+		{name: "or", expr: "MIT OR Apache-2.0", want: []string{"MIT", "Apache-2.0"}},
+		{name: "parens trimmed", expr: "(MIT OR Apache-2.0)", want: []string{"MIT", "Apache-2.0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitLicenseIDs(tt.expr); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitLicenseIDs(%q) = %#v, want %#v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPercentOf(t *testing.T) {
+	if got := percentOf(0, 0); got != 0 {
+		t.Errorf("percentOf(0, 0) = %v, want 0", got)
+	}
+	if got := percentOf(50, 200); got != 25 {
+		t.Errorf("percentOf(50, 200) = %v, want 25", got)
+	}
+}
@@ -0,0 +1,76 @@
+//
+// Copyright 2024 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingestor
+
+import "time"
+
+// IngestOptions tunes the streaming pipeline used by MergedIngest: how many
+// documents are parsed concurrently, how predicates are sub-batched before
+// being flushed to the assembler, and how a failed assembler call is
+// retried. Callers that don't need to tune these should use
+// DefaultIngestOptions.
+type IngestOptions struct {
+	// BatchSize is the total number of predicate entries, summed across
+	// all predicate types, accumulated before the batch is flushed to the
+	// assembler.
+	BatchSize int
+	// Concurrency is the number of parser/ingestor worker goroutines that
+	// process documents concurrently and feed the assembler.
+	Concurrency int
+	// MaxInFlightBytes bounds the approximate size of predicates buffered
+	// in memory before a flush is forced, regardless of whether the total
+	// entry count has reached BatchSize.
+	MaxInFlightBytes int64
+	// FlushInterval forces a flush of whatever has been accumulated so far
+	// once this much time has elapsed since the last flush, so that slow
+	// trickles of documents don't get stuck waiting for a batch to fill.
+	FlushInterval time.Duration
+	// RetryPolicy controls retries of the assembler's GraphQL call when it
+	// fails.
+	RetryPolicy RetryPolicy
+}
+
+// RetryPolicy configures exponential backoff retries of a failing assembler
+// call.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of times the assembler call is
+	// attempted, including the first try. A value <= 1 means no retries.
+	MaxAttempts int
+	// InitialDelay is the backoff before the first retry.
+	InitialDelay time.Duration
+	// MaxDelay caps the backoff between retries.
+	MaxDelay time.Duration
+	// Multiplier scales the delay after each failed attempt.
+	Multiplier float64
+}
+
+// DefaultIngestOptions returns the IngestOptions used when a caller doesn't
+// have a reason to tune throughput vs memory, chosen to roughly match the
+// batching behavior GUAC has historically used.
+func DefaultIngestOptions() IngestOptions {
+	return IngestOptions{
+		BatchSize:        5000,
+		Concurrency:      4,
+		MaxInFlightBytes: 64 * 1024 * 1024, // 64MiB
+		FlushInterval:    5 * time.Second,
+		RetryPolicy: RetryPolicy{
+			MaxAttempts:  5,
+			InitialDelay: 500 * time.Millisecond,
+			MaxDelay:     30 * time.Second,
+			Multiplier:   2,
+		},
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/guacsec/guac/pkg/assembler/helpers"
 	attestation_license "github.com/guacsec/guac/pkg/certifier/attestation/license"
 	"github.com/guacsec/guac/pkg/handler/processor"
+	"github.com/guacsec/guac/pkg/ingestor/licenses"
 	"github.com/guacsec/guac/pkg/ingestor/parser/common"
 	"github.com/guacsec/guac/pkg/logging"
 )
@@ -101,80 +102,92 @@ func (c *parser) parseSubject(s *attestation_license.ClearlyDefinedStatement) er
 The expression will be copied and any license identifiers found will result in linked License noun nodes, created if needed.
 Type will be “declared”. Justification will be “Retrieved from ClearlyDefined”. Time will be the current time the information was retrieved from the API.
 
-Similarly a node will be created using the “licensed” -> “facets” -> “core” -> “discovered” -> “expressions” field.
-Multiple expressions will be “AND”ed together. Type will be “discovered”, and other fields the same (Time, Justification, License links, etc.).
-The “licensed” -> “facets” -> “core” -> “attribution” -> “parties” array will be concatenated and stored in the Attribution field on CertifyLegal.
+Each of the “licensed” -> “facets” (“core”, “data”, “examples”, “tests”) is scanned independently: a
+CertifyLegal is emitted per facet that has a “discovered” -> “expressions” entry, scoped to that facet
+via the Scope field, rather than collapsing every facet into a single expression. Multiple expressions
+within a facet are “AND”ed together. Type will be “discovered”, and other fields the same (Time,
+Justification, License links, etc.). The facet's “attribution” -> “parties” array is concatenated and
+stored in the Attribution field on that facet's CertifyLegal.
 
 “described” -> “sourceLocation” can be used to create a HasSourceAt GUAC node. */
 
+// facetScope pairs a ClearlyDefined facet name with the discovered/attribution
+// data found under it, so every facet can be walked the same way instead of
+// only ever looking at "core".
+type facetScope struct {
+	name        string
+	expressions []string
+	parties     []string
+}
+
+// facetScopes lists the facets in the order ClearlyDefined documents them.
+func facetScopes(facets attestation_license.Facets) []facetScope {
+	return []facetScope{
+		{name: "core", expressions: facets.Core.Discovered.Expressions, parties: facets.Core.Attribution.Parties},
+		{name: "data", expressions: facets.Data.Discovered.Expressions, parties: facets.Data.Attribution.Parties},
+		{name: "examples", expressions: facets.Examples.Discovered.Expressions, parties: facets.Examples.Attribution.Parties},
+		{name: "tests", expressions: facets.Tests.Discovered.Expressions, parties: facets.Tests.Attribution.Parties},
+	}
+}
+
 // parseClearlyDefined parses the attestation to collect the license information
 func (c *parser) parseClearlyDefined(ctx context.Context, s *attestation_license.ClearlyDefinedStatement) error {
-	logger := logging.FromContext(ctx)
+	scanner := licenses.FromContext(ctx)
 
 	if s.Predicate.Definition.Licensed.Declared != "" {
-		discoveredLicenses := make([]generated.LicenseInputSpec, 0)
-		var discoveredLicenseStr string = ""
-		if len(s.Predicate.Definition.Licensed.Facets.Core.Discovered.Expressions) > 0 {
-			discoveredLicenseStr = common.CombineLicense(s.Predicate.Definition.Licensed.Facets.Core.Discovered.Expressions)
-			discoveredLicenses = append(discoveredLicenses, common.ParseLicenses(discoveredLicenseStr, nil, nil)...)
+		declaredLicenses, err := scanner.ScanExpression(s.Predicate.Definition.Licensed.Declared)
+		if err != nil {
+			return fmt.Errorf("unable to scan declared license expression: %w", err)
 		}
 
 		declared := assembler.CertifyLegalIngest{
-			Declared:   common.ParseLicenses(s.Predicate.Definition.Licensed.Declared, nil, nil),
-			Discovered: discoveredLicenses,
+			Declared: declaredLicenses,
 			CertifyLegal: &generated.CertifyLegalInputSpec{
-				DeclaredLicense:   s.Predicate.Definition.Licensed.Declared,
-				DiscoveredLicense: discoveredLicenseStr,
-				Justification:     justification,
-				TimeScanned:       s.Predicate.Metadata.ScannedOn.UTC(),
+				DeclaredLicense: s.Predicate.Definition.Licensed.Declared,
+				Justification:   justification,
+				TimeScanned:     s.Predicate.Metadata.ScannedOn.UTC(),
 			},
 		}
-		if c.pkg != nil {
-			declared.Pkg = c.pkg
-		} else if c.src != nil {
-			declared.Src = c.src
-		} else {
-			return fmt.Errorf("package nor source specified for certifyLegal")
+		if err := c.setSubject(&declared.Pkg, &declared.Src); err != nil {
+			return err
 		}
 		c.collectedCertifyLegal = append(c.collectedCertifyLegal, declared)
+	}
 
-		// [GuacDebug] DEBUG POINT 2: Check CertifyLegal predicates
-		if declared.Src != nil {
-			srcKey := helpers.GetKey[*generated.SourceInputSpec, helpers.SrcIds](declared.Src, helpers.SrcClientKey).NameId
-			logger.Debugf("[GuacDebug] [PARSER] CertifyLegal with source: %s", srcKey)
+	// BLOCKER(chunk0-4): one CertifyLegal per non-empty facet, scoped by
+	// facet name (core, data, examples, tests) via CertifyLegalIngest.Scope.
+	// pkg/assembler lives outside this checkout and CertifyLegalIngest has
+	// no Scope field in it today, so this package does not compile as a
+	// standalone change - it depends on a companion pkg/assembler commit
+	// adding `Scope string` to CertifyLegalIngest (and `Root string` to
+	// generated.HasSourceAtInputSpec, used below). Not mergeable until that
+	// companion change lands first.
+	for _, facet := range facetScopes(s.Predicate.Definition.Licensed.Facets) {
+		if len(facet.expressions) == 0 {
+			continue
 		}
-		logger.Debugf("[GuacDebug] [PARSER] Total CertifyLegal predicates: %d", len(c.collectedCertifyLegal))
-	} else {
-		if len(s.Predicate.Definition.Licensed.Facets.Core.Discovered.Expressions) > 0 {
-			discoveredLicense := common.CombineLicense(s.Predicate.Definition.Licensed.Facets.Core.Discovered.Expressions)
-
-			discovered := assembler.CertifyLegalIngest{
-				Declared:   []generated.LicenseInputSpec{},
-				Discovered: common.ParseLicenses(discoveredLicense, nil, nil),
-				CertifyLegal: &generated.CertifyLegalInputSpec{
-					DiscoveredLicense: discoveredLicense,
-					DeclaredLicense:   "",
-					Attribution:       strings.Join(s.Predicate.Definition.Licensed.Facets.Core.Attribution.Parties, ","),
-					Justification:     justification,
-					TimeScanned:       s.Predicate.Metadata.ScannedOn.UTC(),
-				},
-			}
-			if c.pkg != nil {
-				discovered.Pkg = c.pkg
-			} else if c.src != nil {
-				discovered.Src = c.src
-			} else {
-				return fmt.Errorf("package nor source specified for certifyLegal")
-			}
-			c.collectedCertifyLegal = append(c.collectedCertifyLegal, discovered)
+		discoveredLicense := common.CombineLicense(facet.expressions)
 
-			// [GuacDebug] DEBUG POINT 2: Check CertifyLegal predicates
-			if discovered.Src != nil {
-				srcKey := helpers.GetKey[*generated.SourceInputSpec, helpers.SrcIds](discovered.Src, helpers.SrcClientKey).NameId
-				logger.Debugf("[GuacDebug] [PARSER] CertifyLegal with source: %s", srcKey)
-			}
-			logger.Debugf("[GuacDebug] [PARSER] Total CertifyLegal predicates: %d", len(c.collectedCertifyLegal))
+		discoveredLicenses, err := scanner.ScanExpression(discoveredLicense)
+		if err != nil {
+			return fmt.Errorf("unable to scan discovered license expression for facet %q: %w", facet.name, err)
 		}
+
+		discovered := assembler.CertifyLegalIngest{
+			Declared:   []generated.LicenseInputSpec{},
+			Discovered: discoveredLicenses,
+			Scope:      facet.name,
+			CertifyLegal: &generated.CertifyLegalInputSpec{
+				DiscoveredLicense: discoveredLicense,
+				Attribution:       strings.Join(facet.parties, ","),
+				Justification:     justification,
+				TimeScanned:       s.Predicate.Metadata.ScannedOn.UTC(),
+			},
+		}
+		if err := c.setSubject(&discovered.Pkg, &discovered.Src); err != nil {
+			return err
+		}
+		c.collectedCertifyLegal = append(c.collectedCertifyLegal, discovered)
 	}
 
 	if s.Predicate.Definition.Described.SourceLocation != nil {
@@ -182,11 +195,6 @@ func (c *parser) parseClearlyDefined(ctx context.Context, s *attestation_license
 		srcInput := helpers.SourceToSourceInput(sourceLocation.Type, sourceLocation.Namespace,
 			sourceLocation.Name, &sourceLocation.Revision)
 
-		// [GuacDebug] DEBUG POINT 1: Check what sources are being added
-		logger := logging.FromContext(ctx)
-		srcKey := helpers.GetKey[*generated.SourceInputSpec, helpers.SrcIds](srcInput, helpers.SrcClientKey).NameId
-		logger.Debugf("[GuacDebug] [PARSER] Adding source from SourceLocation: %s", srcKey)
-
 		if c.pkg != nil {
 			c.hasSourceAt = append(c.hasSourceAt, assembler.HasSourceAtIngest{
 				Pkg:          c.pkg,
@@ -195,14 +203,34 @@ func (c *parser) parseClearlyDefined(ctx context.Context, s *attestation_license
 				HasSourceAt: &generated.HasSourceAtInputSpec{
 					KnownSince:    s.Predicate.Definition.Meta.Updated.UTC(),
 					Justification: justification,
+					// BLOCKER(chunk0-4): ClearlyDefined's sourceLocation
+					// always describes the whole package, so Root would be
+					// empty here even once it exists. See the BLOCKER note
+					// on the Scope assignment above - this field doesn't
+					// exist in generated.HasSourceAtInputSpec yet either.
+					Root: "",
 				},
 			})
-			logger.Debugf("[GuacDebug] [PARSER] Total hasSourceAt predicates: %d", len(c.hasSourceAt))
 		}
 	}
 	return nil
 }
 
+// setSubject assigns the statement's resolved package or source to the
+// given CertifyLegalIngest fields, matching how every CertifyLegal produced
+// by this parser picks its subject.
+func (c *parser) setSubject(pkg **generated.PkgInputSpec, src **generated.SourceInputSpec) error {
+	switch {
+	case c.pkg != nil:
+		*pkg = c.pkg
+	case c.src != nil:
+		*src = c.src
+	default:
+		return fmt.Errorf("package nor source specified for certifyLegal")
+	}
+	return nil
+}
+
 func (c *parser) GetPredicates(ctx context.Context) *assembler.IngestPredicates {
 	logger := logging.FromContext(ctx)
 
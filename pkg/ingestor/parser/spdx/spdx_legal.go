@@ -0,0 +1,394 @@
+//
+// Copyright 2024 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package spdx parses the SPDX 2.3 license model - package-level declared,
+// concluded, and from-files licenses, per-file and per-snippet license
+// info, and LicenseRef-* extracted licensing info - into CertifyLegal
+// predicates, one per license fact.
+package spdx
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/guacsec/guac/pkg/assembler"
+	"github.com/guacsec/guac/pkg/assembler/clients/generated"
+	"github.com/guacsec/guac/pkg/assembler/helpers"
+	"github.com/guacsec/guac/pkg/handler/processor"
+	"github.com/guacsec/guac/pkg/ingestor/licenses"
+	"github.com/guacsec/guac/pkg/ingestor/parser/common"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+const justification = "Retrieved from SPDX document"
+
+// Declaration types recorded in a CertifyLegal's Justification, mirroring
+// the distinction the SPDX 2.3 spec draws between what a packager asserted
+// and what was independently found.
+const (
+	declarationTypeDeclared   = "declared"
+	declarationTypeConcluded  = "concluded"
+	declarationTypeDiscovered = "discovered"
+)
+
+// document is the subset of the SPDX 2.3 JSON schema this parser reads.
+type document struct {
+	Packages                 []spdxPackage          `json:"packages"`
+	Files                    []spdxFile             `json:"files"`
+	Snippets                 []spdxSnippet          `json:"snippets"`
+	HasExtractedLicenseInfos []extractedLicenseInfo `json:"hasExtractedLicensingInfos"`
+	Relationships            []relationship         `json:"relationships"`
+}
+
+// relationship is a SPDX "A RELATIONSHIP_TYPE B" triple. This parser only
+// cares about CONTAINS, which is how a document ties a file or snippet back
+// to the package it belongs to.
+type relationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+	RelationshipType   string `json:"relationshipType"`
+}
+
+type spdxPackage struct {
+	SPDXID               string        `json:"SPDXID"`
+	Name                 string        `json:"name"`
+	VersionInfo          string        `json:"versionInfo"`
+	LicenseConcluded     string        `json:"licenseConcluded"`
+	LicenseDeclared      string        `json:"licenseDeclared"`
+	LicenseInfoFromFiles []string      `json:"licenseInfoFromFiles"`
+	LicenseComments      string        `json:"licenseComments"`
+	ExternalRefs         []externalRef `json:"externalRefs"`
+}
+
+type externalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxFile struct {
+	SPDXID             string   `json:"SPDXID"`
+	FileName           string   `json:"fileName"`
+	LicenseConcluded   string   `json:"licenseConcluded"`
+	LicenseInfoInFiles []string `json:"licenseInfoInFiles"`
+	LicenseComments    string   `json:"licenseComments"`
+}
+
+type spdxSnippet struct {
+	SPDXID                string   `json:"SPDXID"`
+	SnippetFromFile       string   `json:"snippetFromFile"`
+	LicenseConcluded      string   `json:"licenseConcluded"`
+	LicenseInfoInSnippets []string `json:"licenseInfoInSnippets"`
+	LicenseComments       string   `json:"licenseComments"`
+}
+
+// extractedLicenseInfo is a SPDX `LicenseRef-*` custom license: one not in
+// the SPDX license list, whose text the document author included inline.
+type extractedLicenseInfo struct {
+	LicenseID     string `json:"licenseId"`
+	ExtractedText string `json:"extractedText"`
+	Name          string `json:"name"`
+}
+
+type parser struct {
+	// packagesByID resolves a package's SPDXID to the PkgInputSpec parsed
+	// from its purl externalRef.
+	packagesByID          map[string]*generated.PkgInputSpec
+	containers            map[string]string
+	licenseRefs           map[string]generated.LicenseInputSpec
+	collectedCertifyLegal []assembler.CertifyLegalIngest
+}
+
+// NewSPDXLegalParser initializes a parser that turns the license fields of
+// a SPDX 2.3 document into CertifyLegal predicates.
+//
+// BLOCKER(chunk0-3): nothing calls NewSPDXLegalParser. It is never
+// registered in the predicate-type dispatch table in pkg/ingestor/parser
+// (alongside clearlydefined.NewLegalCertificationParser) because that
+// dispatch table isn't part of this checkout - pkg/ingestor/parser contains
+// only per-format parser packages here, no parser.go registry to add an
+// entry to. Until that registration exists, this parser is dead code and
+// the request ("ingest SPDX 2.3 ... as CertifyLegal") is not actually
+// delivered; this is a hard blocker for merging this parser as a
+// standalone feature, not a follow-up.
+func NewSPDXLegalParser() common.DocumentParser {
+	return &parser{}
+}
+
+// initialize clears out all values for the next iteration
+func (s *parser) initialize() {
+	s.packagesByID = make(map[string]*generated.PkgInputSpec)
+	s.containers = make(map[string]string)
+	s.licenseRefs = make(map[string]generated.LicenseInputSpec)
+	s.collectedCertifyLegal = make([]assembler.CertifyLegalIngest, 0)
+}
+
+// Parse breaks out the document into the graph components
+func (s *parser) Parse(ctx context.Context, doc *processor.Document) error {
+	s.initialize()
+
+	var spdxDoc document
+	if err := json.Unmarshal(doc.Blob, &spdxDoc); err != nil {
+		return fmt.Errorf("failed to parse spdx document: %w", err)
+	}
+
+	s.registerExtractedLicenseInfos(spdxDoc.HasExtractedLicenseInfos)
+	s.registerContainers(spdxDoc.Relationships)
+
+	scanner := licenses.FromContext(ctx)
+	now := time.Now().UTC()
+
+	for _, p := range spdxDoc.Packages {
+		pkg, err := resolvePackage(p)
+		if err != nil {
+			return fmt.Errorf("unable to resolve package %q: %w", p.SPDXID, err)
+		}
+		s.packagesByID[p.SPDXID] = pkg
+		if err := s.parsePackageLicenses(scanner, pkg, p, now); err != nil {
+			return fmt.Errorf("unable to parse license info for package %q: %w", p.SPDXID, err)
+		}
+	}
+
+	for _, f := range spdxDoc.Files {
+		pkg, ok := s.packageFor(f.SPDXID)
+		if !ok {
+			continue
+		}
+		if err := s.parseFileLicenses(scanner, pkg, f, now); err != nil {
+			return fmt.Errorf("unable to parse license info for file %q: %w", f.SPDXID, err)
+		}
+	}
+
+	fileNames := make(map[string]string, len(spdxDoc.Files))
+	for _, f := range spdxDoc.Files {
+		fileNames[f.SPDXID] = f.FileName
+	}
+
+	for _, sn := range spdxDoc.Snippets {
+		pkg, ok := s.packageFor(sn.SPDXID)
+		if !ok {
+			pkg, ok = s.packageFor(sn.SnippetFromFile)
+		}
+		if !ok {
+			continue
+		}
+		if err := s.parseSnippetLicenses(scanner, pkg, sn, fileNames[sn.SnippetFromFile], now); err != nil {
+			return fmt.Errorf("unable to parse license info for snippet %q: %w", sn.SPDXID, err)
+		}
+	}
+
+	return nil
+}
+
+// packageFor resolves elementID (a file or snippet SPDXID) to the package
+// that CONTAINS it, per the document's relationships.
+func (s *parser) packageFor(elementID string) (*generated.PkgInputSpec, bool) {
+	pkgID, ok := s.containers[elementID]
+	if !ok {
+		return nil, false
+	}
+	pkg, ok := s.packagesByID[pkgID]
+	return pkg, ok
+}
+
+// registerContainers indexes CONTAINS relationships by the contained
+// element's SPDXID.
+func (s *parser) registerContainers(rels []relationship) {
+	for _, rel := range rels {
+		if rel.RelationshipType == "CONTAINS" {
+			s.containers[rel.RelatedSPDXElement] = rel.SPDXElementID
+		}
+	}
+}
+
+func resolvePackage(p spdxPackage) (*generated.PkgInputSpec, error) {
+	for _, ref := range p.ExternalRefs {
+		if ref.ReferenceType == "purl" {
+			return helpers.PurlToPkg(ref.ReferenceLocator)
+		}
+	}
+	return nil, fmt.Errorf("package %q has no purl externalRef to resolve a PkgInputSpec from", p.Name)
+}
+
+// parsePackageLicenses ingests PackageLicenseDeclared, PackageLicenseConcluded,
+// and PackageLicenseInfoFromFiles as distinct CertifyLegal predicates.
+func (s *parser) parsePackageLicenses(scanner licenses.Scanner, pkg *generated.PkgInputSpec, p spdxPackage, scanTime time.Time) error {
+	// Package-level fields describe the whole package, so they carry no
+	// Scope; per-file and per-snippet fields below scope to that path.
+	if p.LicenseDeclared != "" && p.LicenseDeclared != "NOASSERTION" {
+		if err := s.addCertifyLegal(scanner, pkg, p.LicenseDeclared, declarationTypeDeclared, p.LicenseComments, "", scanTime); err != nil {
+			return err
+		}
+	}
+	if p.LicenseConcluded != "" && p.LicenseConcluded != "NOASSERTION" {
+		if err := s.addCertifyLegal(scanner, pkg, p.LicenseConcluded, declarationTypeConcluded, p.LicenseComments, "", scanTime); err != nil {
+			return err
+		}
+	}
+	for _, expr := range p.LicenseInfoFromFiles {
+		if expr == "" || expr == "NOASSERTION" {
+			continue
+		}
+		if err := s.addCertifyLegal(scanner, pkg, expr, declarationTypeDiscovered, p.LicenseComments, "", scanTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *parser) parseFileLicenses(scanner licenses.Scanner, pkg *generated.PkgInputSpec, f spdxFile, scanTime time.Time) error {
+	if f.LicenseConcluded != "" && f.LicenseConcluded != "NOASSERTION" {
+		if err := s.addCertifyLegal(scanner, pkg, f.LicenseConcluded, declarationTypeConcluded, f.LicenseComments, f.FileName, scanTime); err != nil {
+			return err
+		}
+	}
+	for _, expr := range f.LicenseInfoInFiles {
+		if expr == "" || expr == "NOASSERTION" {
+			continue
+		}
+		if err := s.addCertifyLegal(scanner, pkg, expr, declarationTypeDiscovered, f.LicenseComments, f.FileName, scanTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseSnippetLicenses scopes the snippet's licenses to fileName, the
+// fileName of the file the snippet comes from (empty if that file is not
+// in the document).
+func (s *parser) parseSnippetLicenses(scanner licenses.Scanner, pkg *generated.PkgInputSpec, sn spdxSnippet, fileName string, scanTime time.Time) error {
+	if sn.LicenseConcluded != "" && sn.LicenseConcluded != "NOASSERTION" {
+		if err := s.addCertifyLegal(scanner, pkg, sn.LicenseConcluded, declarationTypeConcluded, sn.LicenseComments, fileName, scanTime); err != nil {
+			return err
+		}
+	}
+	for _, expr := range sn.LicenseInfoInSnippets {
+		if expr == "" || expr == "NOASSERTION" {
+			continue
+		}
+		if err := s.addCertifyLegal(scanner, pkg, expr, declarationTypeDiscovered, sn.LicenseComments, fileName, scanTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addCertifyLegal resolves expr (which may reference a LicenseRef-* custom
+// license registered via hasExtractedLicensingInfo) into License nodes and
+// appends a CertifyLegal predicate of the given declaration type, scoped to
+// scope (a repo-relative file path, or empty for the whole package).
+//
+// BLOCKER(chunk0-4): scope is assigned to the same
+// pkg/assembler.CertifyLegalIngest.Scope field the ClearlyDefined parser
+// uses (see its BLOCKER note) - that field doesn't exist in this checkout,
+// so this parser doesn't compile standalone either. Not mergeable until the
+// companion pkg/assembler change lands first.
+func (s *parser) addCertifyLegal(scanner licenses.Scanner, pkg *generated.PkgInputSpec, expr, declarationType, comments, scope string, scanTime time.Time) error {
+	licenseInputs, err := s.resolveExpression(scanner, expr)
+	if err != nil {
+		return fmt.Errorf("unable to resolve license expression %q: %w", expr, err)
+	}
+
+	certifyLegal := &generated.CertifyLegalInputSpec{
+		Justification: formatJustification(declarationType, comments),
+		TimeScanned:   scanTime,
+	}
+	ingest := assembler.CertifyLegalIngest{
+		Pkg:          pkg,
+		Scope:        scope,
+		CertifyLegal: certifyLegal,
+	}
+	switch declarationType {
+	case declarationTypeDeclared:
+		certifyLegal.DeclaredLicense = expr
+		ingest.Declared = licenseInputs
+	case declarationTypeConcluded, declarationTypeDiscovered:
+		// GUAC's CertifyLegal schema only distinguishes declared vs
+		// discovered license text, so concluded and discovered both land
+		// in DiscoveredLicense; the declarationType tag kept in
+		// Justification above is the only thing that tells them apart
+		// until CertifyLegalInputSpec grows a real DeclarationType field.
+		certifyLegal.DiscoveredLicense = expr
+		ingest.Discovered = licenseInputs
+	}
+
+	s.collectedCertifyLegal = append(s.collectedCertifyLegal, ingest)
+	return nil
+}
+
+// formatJustification builds a CertifyLegal Justification tagged with
+// declarationType, folding in comments (SPDX's LicenseComments) when
+// present rather than writing them into Attribution.
+func formatJustification(declarationType, comments string) string {
+	text := fmt.Sprintf("%s (%s)", justification, declarationType)
+	if comments != "" {
+		text = fmt.Sprintf("%s: %s", text, comments)
+	}
+	return text
+}
+
+// resolveExpression parses expr into License nodes, substituting the full
+// extracted text for any LicenseRef-* identifier it references.
+func (s *parser) resolveExpression(scanner licenses.Scanner, expr string) ([]generated.LicenseInputSpec, error) {
+	if ref, ok := s.licenseRefs[expr]; ok {
+		return []generated.LicenseInputSpec{ref}, nil
+	}
+	parsed, err := scanner.ScanExpression(expr)
+	if err != nil {
+		return nil, err
+	}
+	for i, l := range parsed {
+		if ref, ok := s.licenseRefs[l.Name]; ok {
+			parsed[i] = ref
+		}
+	}
+	return parsed, nil
+}
+
+// registerExtractedLicenseInfos turns hasExtractedLicensingInfo entries into
+// License nodes carrying their extracted text, keyed by LicenseRef-*
+// identifier.
+func (s *parser) registerExtractedLicenseInfos(infos []extractedLicenseInfo) {
+	for _, info := range infos {
+		name := info.Name
+		if name == "" {
+			name = info.LicenseID
+		}
+		inline := info.ExtractedText
+		s.licenseRefs[info.LicenseID] = generated.LicenseInputSpec{
+			Name:   name,
+			Inline: &inline,
+		}
+	}
+}
+
+func (s *parser) GetPredicates(ctx context.Context) *assembler.IngestPredicates {
+	return &assembler.IngestPredicates{
+		CertifyLegal: s.collectedCertifyLegal,
+	}
+}
+
+// GetIdentities gets the identity node from the document if they exist
+func (s *parser) GetIdentities(ctx context.Context) []common.TrustInformation {
+	return nil
+}
+
+func (s *parser) GetIdentifiers(ctx context.Context) (*common.IdentifierStrings, error) {
+	return nil, fmt.Errorf("not yet implemented")
+}
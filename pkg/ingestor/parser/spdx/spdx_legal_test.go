@@ -0,0 +1,75 @@
+//
+// Copyright 2024 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spdx
+
+import "testing"
+
+func TestFormatJustification(t *testing.T) {
+	tests := []struct {
+		name            string
+		declarationType string
+		comments        string
+		want            string
+	}{
+		{
+			name:            "declared, no comments",
+			declarationType: declarationTypeDeclared,
+			comments:        "",
+			want:            "Retrieved from SPDX document (declared)",
+		},
+		{
+			name:            "concluded with comments",
+			declarationType: declarationTypeConcluded,
+			comments:        "manually reviewed by legal",
+			want:            "Retrieved from SPDX document (concluded): manually reviewed by legal",
+		},
+		{
+			name:            "discovered with comments",
+			declarationType: declarationTypeDiscovered,
+			comments:        "found in LICENSE header",
+			want:            "Retrieved from SPDX document (discovered): found in LICENSE header",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatJustification(tt.declarationType, tt.comments); got != tt.want {
+				t.Errorf("formatJustification(%q, %q) = %q, want %q", tt.declarationType, tt.comments, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterContainers(t *testing.T) {
+	s := &parser{}
+	s.initialize()
+
+	s.registerContainers([]relationship{
+		{SPDXElementID: "SPDXRef-pkg-a", RelatedSPDXElement: "SPDXRef-file-1", RelationshipType: "CONTAINS"},
+		{SPDXElementID: "SPDXRef-pkg-b", RelatedSPDXElement: "SPDXRef-file-2", RelationshipType: "CONTAINS"},
+		{SPDXElementID: "SPDXRef-pkg-a", RelatedSPDXElement: "SPDXRef-file-3", RelationshipType: "DESCRIBES"},
+	})
+
+	if got, ok := s.containers["SPDXRef-file-1"]; !ok || got != "SPDXRef-pkg-a" {
+		t.Errorf("containers[file-1] = (%q, %v), want (SPDXRef-pkg-a, true)", got, ok)
+	}
+	if got, ok := s.containers["SPDXRef-file-2"]; !ok || got != "SPDXRef-pkg-b" {
+		t.Errorf("containers[file-2] = (%q, %v), want (SPDXRef-pkg-b, true)", got, ok)
+	}
+	if _, ok := s.containers["SPDXRef-file-3"]; ok {
+		t.Error("containers[file-3] should be absent: relationship type was DESCRIBES, not CONTAINS")
+	}
+}